@@ -0,0 +1,125 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// format identifies a config file's on-disk encoding.
+type format int
+
+const (
+	formatJSON format = iota
+	formatYAML
+	formatTOML
+)
+
+func (f format) String() string {
+	switch f {
+	case formatYAML:
+		return "yaml"
+	case formatTOML:
+		return "toml"
+	default:
+		return "json"
+	}
+}
+
+// formatFromExt picks a format by file extension, defaulting to JSON for an
+// unrecognized or missing extension.
+func formatFromExt(path string) format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+// formatFromName parses a --config-format value such as "yaml" or "json".
+func formatFromName(name string) (format, error) {
+	switch strings.ToLower(name) {
+	case "", "json":
+		return formatJSON, nil
+	case "yaml", "yml":
+		return formatYAML, nil
+	case "toml":
+		return formatTOML, nil
+	default:
+		return formatJSON, fmt.Errorf("unsupported config format: %s", name)
+	}
+}
+
+// decodeConfig reads cfg from r in the given format.
+func decodeConfig(r io.Reader, f format, cfg *Config) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	switch f {
+	case formatYAML:
+		return yaml.Unmarshal(data, cfg)
+	case formatTOML:
+		return toml.Unmarshal(data, cfg)
+	default:
+		return json.Unmarshal(data, cfg)
+	}
+}
+
+// decodeConfigDoc parses data in the given format into a generic document,
+// for callers (migrations) that need to inspect or rewrite fields the
+// current Config struct doesn't know about, e.g. from an older schema
+// version.
+func decodeConfigDoc(data []byte, f format) (map[string]any, error) {
+	doc := map[string]any{}
+	var err error
+	switch f {
+	case formatYAML:
+		err = yaml.Unmarshal(data, &doc)
+	case formatTOML:
+		err = toml.Unmarshal(data, &doc)
+	default:
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// encodeConfig writes cfg to w in the given format.
+func encodeConfig(w io.Writer, f format, cfg *Config) error {
+	switch f {
+	case formatYAML:
+		return yaml.NewEncoder(w).Encode(cfg)
+	case formatTOML:
+		return toml.NewEncoder(w).Encode(cfg)
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cfg)
+	}
+}
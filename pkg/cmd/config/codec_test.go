@@ -0,0 +1,53 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import "testing"
+
+func TestFormatFromExt(t *testing.T) {
+	tests := map[string]format{
+		"config.json": formatJSON,
+		"config.yaml": formatYAML,
+		"config.yml":  formatYAML,
+		"config.toml": formatTOML,
+		"config":      formatJSON,
+	}
+	for path, want := range tests {
+		if got := formatFromExt(path); got != want {
+			t.Errorf("formatFromExt(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestSaveConfigRoundTripsAcrossFormats(t *testing.T) {
+	for _, ext := range []string{"config.json", "config.yaml", "config.toml"} {
+		t.Run(ext, func(t *testing.T) {
+			path := t.TempDir() + "/" + ext
+			want := &Config{LogLevel: "debug", Progress: "fancy"}
+			if err := SaveConfig(want, path); err != nil {
+				t.Fatalf("SaveConfig() error = %v", err)
+			}
+
+			got, err := loadFileConfig(path)
+			if err != nil {
+				t.Fatalf("loadFileConfig() error = %v", err)
+			}
+			if got.LogLevel != want.LogLevel || got.Progress != want.Progress {
+				t.Errorf("loadFileConfig() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -17,147 +17,488 @@ package config
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"kitops/pkg/cmd/config/migrations"
 	"kitops/pkg/output"
 	"os"
 	"path/filepath"
-	"reflect"
-	"strings"
+	"sort"
+
+	"github.com/spf13/viper"
 )
 
+// nestedListKeys are the dotted paths under logging/storage that listConfig
+// shows alongside the flat Keys(), in the same sorted block.
+var nestedListKeys = []string{
+	"logging.level",
+	"logging.format",
+	"logging.file",
+	"logging.time_format",
+	"storage.location",
+	"storage.gc.enabled",
+	"storage.gc.ttl",
+}
+
+// Config holds the effective, merged configuration for the CLI. Values are
+// composed, in increasing order of precedence, from built-in defaults, a
+// config file, environment variables prefixed KITOPS_, and command-line
+// flags. See LoadConfig.
+//
+// LogLevel, Progress, and ConfigDir remain flat top-level keys for backwards
+// compatibility with existing config files; new structured settings live
+// under the Registries/Storage/Logging sections instead. The v1->v2
+// migration (see pkg/cmd/config/migrations) moves an older file's
+// config_dir into storage.location; log_level and progress are not
+// migrated.
 type Config struct {
-	LogLevel  string `json:"log_level"`
-	Progress  string `json:"progress"`
-	ConfigDir string `json:"config_dir"`
+	LogLevel  string `json:"log_level" mapstructure:"log_level" yaml:"log_level" toml:"log_level"`
+	Progress  string `json:"progress" mapstructure:"progress" yaml:"progress" toml:"progress"`
+	ConfigDir string `json:"config_dir" mapstructure:"config_dir" yaml:"config_dir" toml:"config_dir"`
+
+	Registries []RegistryConfig `json:"registries,omitempty" mapstructure:"registries" yaml:"registries,omitempty" toml:"registries,omitempty"`
+	Storage    StorageConfig    `json:"storage,omitempty" mapstructure:"storage" yaml:"storage,omitempty" toml:"storage,omitempty"`
+	Logging    LoggingConfig    `json:"logging,omitempty" mapstructure:"logging" yaml:"logging,omitempty" toml:"logging,omitempty"`
+
+	// Inherits names a parent profile whose values are merged underneath
+	// this one. It is only meaningful on profile config files; see
+	// loadMergedProfileConfig.
+	Inherits string `json:"inherits,omitempty" mapstructure:"inherits" yaml:"inherits,omitempty" toml:"inherits,omitempty"`
+
+	// SchemaVersion records which version of the config schema this file
+	// was written in. loadFileConfig migrates older files up to
+	// migrations.CurrentVersion before they're ever parsed into this
+	// struct; see pkg/cmd/config/migrations.
+	SchemaVersion int `json:"schema_version" mapstructure:"schema_version" yaml:"schema_version" toml:"schema_version"`
+}
+
+// AuthConfig describes how to authenticate against a registry. Fields
+// tagged sensitive:"true" name an env var rather than holding a secret
+// directly; listConfig shows them as "<from env NAME>", never the value
+// of that env var (see authFields).
+type AuthConfig struct {
+	Type                string `json:"type,omitempty" mapstructure:"type" yaml:"type,omitempty" toml:"type,omitempty"` // basic|token|ssh
+	Username            string `json:"username,omitempty" mapstructure:"username" yaml:"username,omitempty" toml:"username,omitempty"`
+	PasswordEnv         string `json:"password_env,omitempty" mapstructure:"password_env" yaml:"password_env,omitempty" toml:"password_env,omitempty" sensitive:"true"`
+	TokenEnv            string `json:"token_env,omitempty" mapstructure:"token_env" yaml:"token_env,omitempty" toml:"token_env,omitempty" sensitive:"true"`
+	SSHKeyPath          string `json:"ssh_key_path,omitempty" mapstructure:"ssh_key_path" yaml:"ssh_key_path,omitempty" toml:"ssh_key_path,omitempty"`
+	SSHKeyPassphraseEnv string `json:"ssh_key_passphrase_env,omitempty" mapstructure:"ssh_key_passphrase_env" yaml:"ssh_key_passphrase_env,omitempty" toml:"ssh_key_passphrase_env,omitempty" sensitive:"true"`
+}
+
+// RegistryConfig holds the connection details for one named registry.
+type RegistryConfig struct {
+	Name string     `json:"name" mapstructure:"name" yaml:"name" toml:"name"`
+	URL  string     `json:"url" mapstructure:"url" yaml:"url" toml:"url"`
+	Auth AuthConfig `json:"auth,omitempty" mapstructure:"auth" yaml:"auth,omitempty" toml:"auth,omitempty"`
+}
+
+// GCConfig controls the local storage garbage collector.
+//
+// Enabled is a *bool rather than a bool so that mergeConfig can tell "a
+// profile explicitly set this to false" apart from "a profile didn't
+// mention it at all" — with a plain bool, both look like the zero value
+// and an override could never turn an inherited true back off.
+type GCConfig struct {
+	Enabled *bool  `json:"enabled,omitempty" mapstructure:"enabled" yaml:"enabled,omitempty" toml:"enabled,omitempty"`
+	TTL     string `json:"ttl,omitempty" mapstructure:"ttl" yaml:"ttl,omitempty" toml:"ttl,omitempty"`
+}
+
+// StorageConfig controls where and how the CLI stores local data.
+type StorageConfig struct {
+	Location string   `json:"location,omitempty" mapstructure:"location" yaml:"location,omitempty" toml:"location,omitempty"`
+	GC       GCConfig `json:"gc,omitempty" mapstructure:"gc" yaml:"gc,omitempty" toml:"gc,omitempty"`
+}
+
+// LoggingConfig controls CLI log output.
+type LoggingConfig struct {
+	Level      string `json:"level,omitempty" mapstructure:"level" yaml:"level,omitempty" toml:"level,omitempty"`
+	Format     string `json:"format,omitempty" mapstructure:"format" yaml:"format,omitempty" toml:"format,omitempty"`
+	File       string `json:"file,omitempty" mapstructure:"file" yaml:"file,omitempty" toml:"file,omitempty"`
+	TimeFormat string `json:"time_format,omitempty" mapstructure:"time_format" yaml:"time_format,omitempty" toml:"time_format,omitempty"`
 }
 
 // DefaultConfig returns a Config struct with default values.
 func DefaultConfig() *Config {
 	return &Config{
-		LogLevel:  output.LogLevelInfo.String(),
-		Progress:  "plain",
-		ConfigDir: "",
+		LogLevel:      output.LogLevelInfo.String(),
+		Progress:      "plain",
+		ConfigDir:     "",
+		SchemaVersion: migrations.CurrentVersion,
 	}
 }
 
-// Set a configuration key and value.
+// Set a configuration key and value. The read-modify-write is done under an
+// advisory lock so concurrent `kit config set` invocations can't interleave
+// and silently lose one of the updates.
 func setConfig(_ context.Context, opts *configOptions) error {
-	configPath := getConfigPath(opts.profile)
-	cfg, err := LoadConfig(configPath)
-	if err != nil {
-		cfg = DefaultConfig() // Start with defaults if config doesn't exist.
-	}
+	configPath := resolveConfigPath(opts)
 
-	v := reflect.ValueOf(cfg).Elem().FieldByName(strings.Title(opts.key))
-	if !v.IsValid() {
-		return fmt.Errorf("unknown configuration key: %s", opts.key)
-	}
+	return withConfigLock(configPath, func() error {
+		// Back up the pre-migration file, if any, before we overwrite it
+		// below with a migrated (and now also edited) version.
+		if err := backupIfLegacy(configPath); err != nil {
+			return err
+		}
+		// Start from the file layer only: env/flag values must never be
+		// written back to disk, so we deliberately avoid LoadConfig here.
+		cfg, err := loadFileConfig(configPath)
+		if err != nil {
+			return err
+		}
 
-	v.SetString(opts.value)
-	err = SaveConfig(cfg, configPath)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("Config '%s' set to '%s'\n", opts.key, opts.value)
-	return nil
+		if err := cfg.Set(opts.key, opts.value); err != nil {
+			return err
+		}
+		if err := SaveConfig(cfg, configPath); err != nil {
+			return err
+		}
+		fmt.Printf("Config '%s' set to '%s'\n", opts.key, opts.value)
+		return nil
+	})
 }
 
-// Get a configuration value.
+// Get a configuration value, resolved through the full precedence chain
+// (defaults < file < env < flags).
 func getConfig(_ context.Context, opts *configOptions) (string, error) {
-	configPath := getConfigPath(opts.profile)
-	cfg, err := LoadConfig(configPath)
+	cfg, err := LoadConfig(opts)
 	if err != nil {
 		return "", err
 	}
-
-	v := reflect.ValueOf(cfg).Elem().FieldByName(strings.Title(opts.key))
-	if !v.IsValid() {
-		return "", fmt.Errorf("unknown configuration key: %s", opts.key)
-	}
-
-	return fmt.Sprintf("%v", v.Interface()), nil
+	return cfg.Get(opts.key)
 }
 
-// List all configuration values.
+// List all configuration values, resolved through the full precedence chain.
+// Any AuthConfig field tagged sensitive:"true" names an env var rather than
+// holding a secret value, so it's always shown as "<from env NAME>" —
+// opts.showSecrets (--show-secrets) has nothing further to reveal for
+// these fields. See authFields.
 func listConfig(_ context.Context, opts *configOptions) error {
-	configPath := getConfigPath(opts.profile)
-	cfg, err := LoadConfig(configPath)
+	cfg, err := LoadConfig(opts)
 	if err != nil {
 		return err
 	}
 
-	// Use reflection to iterate through fields and print them.
-	v := reflect.ValueOf(cfg).Elem()
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		fmt.Printf("%s: %v\n", t.Field(i).Name, v.Field(i).Interface())
+	values := make(map[string]string, len(Keys())+len(nestedListKeys))
+	var keys []string
+	for _, key := range Keys() {
+		value, err := cfg.Get(key)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		values[key] = value
+	}
+	for _, key := range nestedListKeys {
+		value, err := cfg.Get(key)
+		if err != nil {
+			return err
+		}
+		// A nested field that's still unset would only print a blank
+		// "key: " row, so skip it; this also covers storage.gc.enabled,
+		// whose *bool is nil (Get returns "") when no layer has set it.
+		if value == "" {
+			continue
+		}
+		keys = append(keys, key)
+		values[key] = value
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Printf("%s: %v\n", key, values[key])
+	}
+
+	showSecrets := opts != nil && opts.showSecrets
+	for _, reg := range cfg.Registries {
+		fmt.Printf("registries.%s.url: %v\n", reg.Name, reg.URL)
+		for _, line := range authFields(reg.Auth, showSecrets) {
+			fmt.Printf("registries.%s.%s\n", reg.Name, line)
+		}
 	}
 	return nil
 }
 
 // Reset configuration to defaults.
 func resetConfig(_ context.Context, opts *configOptions) error {
-	configPath := getConfigPath(opts.profile)
+	configPath := resolveConfigPath(opts)
 	cfg := DefaultConfig()
-	err := SaveConfig(cfg, configPath)
-	if err != nil {
+	if err := SaveConfig(cfg, configPath); err != nil {
 		return err
 	}
 	fmt.Println("Configuration reset to default values.")
 	return nil
 }
 
-// Load configuration from a file.
-func LoadConfig(configPath string) (*Config, error) {
+// LoadConfig composes the effective configuration from, in order of
+// increasing precedence: built-in defaults, the resolved config file chain
+// (see resolveConfigChain — the active profile's inheritance chain, root
+// ancestor first, or just the top-level config file if no profile is
+// active), environment variables prefixed KITOPS_ (e.g. KITOPS_LOG_LEVEL),
+// and the key/value override captured on opts, if any (the CLI's
+// equivalent of a flag).
+func LoadConfig(opts *configOptions) (*Config, error) {
+	files, err := resolveConfigChain(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	defaults := DefaultConfig()
+	v.SetDefault("log_level", defaults.LogLevel)
+	v.SetDefault("progress", defaults.Progress)
+	v.SetDefault("config_dir", defaults.ConfigDir)
+
+	for _, configPath := range files {
+		// Read (and, if needed, migrate in memory) each file ourselves
+		// rather than via v.ReadInConfig, so an older on-disk
+		// schema_version is upgraded before its keys are merged in.
+		// LoadConfig is a read path: it must never write a backup or
+		// persist the migration itself (see backupIfLegacy, used by the
+		// write paths that do). Merging the chain in order, root ancestor
+		// first, means a later (more specific) file's keys win, exactly
+		// like loadMergedProfileConfig's struct-level merge.
+		doc, err := loadAndMigrateDoc(configPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		// As in mergeConfig, an empty top-level string means "not set in
+		// this file" and must not mask a value from an earlier file.
+		for key, value := range doc {
+			if s, ok := value.(string); ok && s == "" {
+				delete(doc, key)
+			}
+		}
+		if err := v.MergeConfigMap(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+		}
+	}
+
+	v.SetEnvPrefix("KITOPS")
+	v.AutomaticEnv()
+	for _, key := range Keys() {
+		if err := v.BindEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	// Flags take precedence over everything else.
+	if opts != nil && opts.key != "" && opts.value != "" {
+		if err := cfg.Set(opts.key, opts.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cfg, nil
+}
+
+// loadFileConfig reads only the on-disk config file, ignoring environment
+// variables and flags. This is the layer that SaveConfig persists back to,
+// so that `kit config set` never bakes an env-sourced or flag-sourced value
+// into the file. If the file's schema_version is older than
+// migrations.CurrentVersion, it is migrated in memory before being parsed
+// into a Config; this is a read, so nothing is written to disk here. A
+// caller that's about to persist a migrated Config (setConfig, addRegistry,
+// removeRegistryConfig, migrateConfig) is responsible for calling
+// backupIfLegacy first so the pre-migration file isn't lost.
+func loadFileConfig(configPath string) (*Config, error) {
 	if configPath == "" {
-		return nil, fmt.Errorf("config path is empty")
+		return DefaultConfig(), nil
 	}
 
-	file, err := os.Open(configPath)
+	doc, err := loadAndMigrateDoc(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return DefaultConfig(), nil // Return default config if file doesn't exist.
+			return DefaultConfig(), nil
 		}
 		return nil, err
 	}
-	defer file.Close()
 
-	var config Config
-	if err := json.NewDecoder(file).Decode(&config); err != nil {
-		return nil, err
+	cfg := DefaultConfig()
+	if err := decodeConfigMap(doc, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
 	}
-	return &config, nil
+	return cfg, nil
 }
 
-// Save configuration to a file.
+// SaveConfig writes config to configPath, encoding it according to the
+// file's extension (.json, .yaml/.yml, or .toml; defaulting to JSON). Only
+// the file layer is written; callers are responsible for building config
+// from loadFileConfig (not LoadConfig) so env/flag values aren't persisted.
+//
+// The write is atomic: config is encoded into a sibling "<name>.tmp" file,
+// fsynced, then renamed over configPath, so a crash or a concurrent save
+// can't leave a half-written file on disk.
 func SaveConfig(config *Config, configPath string) error {
-	file, err := os.Create(configPath)
+	dir := filepath.Dir(configPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmpPath := configPath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if err := encodeConfig(file, formatFromExt(configPath), config); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync config file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
 
-	return json.NewEncoder(file).Encode(config)
+	if err := os.Rename(tmpPath, configPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save config file: %w", err)
+	}
+	return nil
 }
 
-// Get the config path, either from the profile or default.
-func getConfigPath(profile string) string {
-	configDir := os.Getenv("KITOPS_HOME")
-	if configDir == "" {
-		homeDir, _ := os.UserHomeDir()
-		configDir = filepath.Join(homeDir, ".kitops")
+// resolveConfigPath determines the single config file that write commands
+// (setConfig, addRegistry, removeRegistryConfig, resetConfig,
+// migrateConfig) should read and persist: the resolved profile's
+// config.json (see resolveProfile) under the resolved home directory (see
+// configBaseDir), or, if the --config flag was given, opts.configHome
+// (rewritten to profiles/<name>/ beside it when opts.profile is set).
+func resolveConfigPath(opts *configOptions) string {
+	if opts != nil && opts.configHome != "" {
+		return withProfile(opts.configHome, profileOf(opts))
 	}
-	if profile != "" {
-		configDir = filepath.Join(configDir, "profiles", profile)
+
+	baseDir := configBaseDir()
+	// A malformed state.json only affects which profile we pick; fall back
+	// to the explicitly-requested profile (if any) rather than failing a
+	// write outright.
+	profile, err := resolveProfile(baseDir, opts)
+	if err != nil {
+		profile = profileOf(opts)
 	}
-	return filepath.Join(configDir, "config.json")
+	return withProfile(filepath.Join(baseDir, "config.json"), profile)
+}
+
+// resolveConfigChain returns the config files that make up the effective
+// configuration, in increasing merge precedence (LoadConfig merges them in
+// this order, so a later file's keys win). With no active profile, this is
+// just the top-level config file. With an active profile — set explicitly
+// via opts.profile, or recorded in state.json by `kit config use` — it's
+// every config.json in that profile's inheritance chain, root ancestor
+// first, the same chain loadMergedProfileConfig walks for
+// `kit config profile show`.
+func resolveConfigChain(opts *configOptions) ([]string, error) {
+	if opts != nil && opts.configHome != "" {
+		return []string{withProfile(opts.configHome, profileOf(opts))}, nil
+	}
+
+	baseDir := configBaseDir()
+	profile, err := resolveProfile(baseDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		return []string{filepath.Join(baseDir, "config.json")}, nil
+	}
+
+	chain, err := resolveProfileChain(baseDir, profile)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, len(chain))
+	for i, name := range chain {
+		files[i] = filepath.Join(baseDir, "profiles", name, "config.json")
+	}
+	return files, nil
+}
+
+// configBaseDir returns the directory holding config.json, profiles/, and
+// state.json: $KITOPS_HOME if set, else the XDG base directory
+// ($XDG_CONFIG_HOME/kitops), falling back to ~/.kitops.
+func configBaseDir() string {
+	if home := os.Getenv("KITOPS_HOME"); home != "" {
+		return home
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "kitops")
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".kitops")
+}
+
+// resolveProfile returns the profile that applies when the caller hasn't
+// pinned one via opts.configHome: opts.profile if set (an explicit
+// --profile flag), otherwise the active profile recorded in baseDir's
+// state.json by `kit config use`, if any. Returns "" if neither is set.
+func resolveProfile(baseDir string, opts *configOptions) (string, error) {
+	if opts != nil && opts.profile != "" {
+		return opts.profile, nil
+	}
+	state, err := loadState(baseDir)
+	if err != nil {
+		return "", err
+	}
+	return state.ActiveProfile, nil
+}
+
+func profileOf(opts *configOptions) string {
+	if opts == nil {
+		return ""
+	}
+	return opts.profile
+}
+
+// withProfile rewrites configPath to live under a profiles/<name> directory
+// when profile is set.
+func withProfile(configPath, profile string) string {
+	if profile == "" {
+		return configPath
+	}
+	return filepath.Join(filepath.Dir(configPath), "profiles", profile, filepath.Base(configPath))
 }
 
 // ConfigOptions struct to store command options.
 type configOptions struct {
-	key        string
-	value      string
-	profile    string
-	configHome string
+	key          string
+	value        string
+	profile      string
+	configHome   string
+	configFormat string // --config-format, used when reading/writing config via stdin/stdout
+	showSecrets  bool   // --show-secrets, used by listConfig
+}
+
+// readConfigStream decodes a Config from r using the explicit format name
+// (e.g. from --config-format), for piping a config in over stdin.
+func readConfigStream(r io.Reader, formatName string) (*Config, error) {
+	f, err := formatFromName(formatName)
+	if err != nil {
+		return nil, err
+	}
+	cfg := DefaultConfig()
+	if err := decodeConfig(r, f, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// writeConfigStream encodes cfg to w using the explicit format name (e.g.
+// from --config-format), for piping a config out over stdout.
+func writeConfigStream(w io.Writer, formatName string, cfg *Config) error {
+	f, err := formatFromName(formatName)
+	if err != nil {
+		return err
+	}
+	return encodeConfig(w, f, cfg)
 }
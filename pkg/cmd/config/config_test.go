@@ -0,0 +1,220 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// captureStdout runs fn with os.Stdout redirected to a pipe and returns
+// everything it printed.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close pipe: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatalf("failed to read pipe: %v", err)
+	}
+	return buf.String()
+}
+
+func writeConfigFile(t *testing.T, path string, cfg *Config) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, configPath, &Config{LogLevel: "warn", Progress: "fancy"})
+
+	// File layer wins over defaults.
+	cfg, err := LoadConfig(&configOptions{configHome: configPath})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "warn" {
+		t.Errorf("LogLevel = %q, want %q (file should override defaults)", cfg.LogLevel, "warn")
+	}
+
+	// Env layer wins over the file.
+	t.Setenv("KITOPS_LOG_LEVEL", "debug")
+	cfg, err = LoadConfig(&configOptions{configHome: configPath})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("LogLevel = %q, want %q (env should override file)", cfg.LogLevel, "debug")
+	}
+
+	// A flag-equivalent override wins over env.
+	cfg, err = LoadConfig(&configOptions{configHome: configPath, key: "log_level", value: "error"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "error" {
+		t.Errorf("LogLevel = %q, want %q (flag should override env)", cfg.LogLevel, "error")
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig(&configOptions{configHome: filepath.Join(t.TempDir(), "missing.json")})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := DefaultConfig()
+	if cfg.LogLevel != want.LogLevel || cfg.Progress != want.Progress {
+		t.Errorf("LoadConfig() = %+v, want defaults %+v", cfg, want)
+	}
+}
+
+func TestConfigSetGetUnknownKey(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.Set("not_a_real_key", "value"); err == nil {
+		t.Error("Set() with unknown key should return an error")
+	}
+	if _, err := cfg.Get("not_a_real_key"); err == nil {
+		t.Error("Get() with unknown key should return an error")
+	}
+
+	if err := cfg.Set("log_level", "debug"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err := cfg.Get("log_level")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "debug" {
+		t.Errorf("Get(log_level) = %q, want %q", got, "debug")
+	}
+}
+
+func TestLoadConfigAppliesActiveProfileChain(t *testing.T) {
+	t.Setenv("KITOPS_HOME", t.TempDir())
+	baseDir := os.Getenv("KITOPS_HOME")
+
+	parent := DefaultConfig()
+	parent.LogLevel = "warn"
+	if err := SaveConfig(parent, filepath.Join(baseDir, "profiles", "base", "config.json")); err != nil {
+		t.Fatalf("failed to write parent profile: %v", err)
+	}
+	child := DefaultConfig()
+	child.Inherits = "base"
+	child.Progress = "fancy"
+	if err := SaveConfig(child, filepath.Join(baseDir, "profiles", "dev", "config.json")); err != nil {
+		t.Fatalf("failed to write child profile: %v", err)
+	}
+
+	// With no active profile and no --profile flag, LoadConfig only sees
+	// the (nonexistent) top-level config file, so it falls back to defaults.
+	cfg, err := LoadConfig(&configOptions{})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != DefaultConfig().LogLevel {
+		t.Errorf("LogLevel = %q, want default before any profile is active", cfg.LogLevel)
+	}
+
+	// `kit config use dev` should make subsequent LoadConfig calls resolve
+	// through dev's inheritance chain without needing an explicit --profile.
+	if err := useProfile(nil, &profileOptions{baseDir: baseDir, name: "dev"}); err != nil {
+		t.Fatalf("useProfile() error = %v", err)
+	}
+	cfg, err = LoadConfig(&configOptions{})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "warn" {
+		t.Errorf("LogLevel = %q, want %q (inherited from base via the active profile)", cfg.LogLevel, "warn")
+	}
+	if cfg.Progress != "fancy" {
+		t.Errorf("Progress = %q, want %q (set on the active profile dev)", cfg.Progress, "fancy")
+	}
+}
+
+func TestListConfigOmitsBlankNestedFieldsAndSortsKeys(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	opts := &configOptions{configHome: configPath}
+
+	out := captureStdout(t, func() {
+		if err := listConfig(nil, opts); err != nil {
+			t.Fatalf("listConfig() error = %v", err)
+		}
+	})
+
+	if strings.Contains(out, "logging.level: \n") || strings.Contains(out, "storage.gc.ttl: \n") {
+		t.Errorf("listConfig() printed a blank row for an unset nested field: %q", out)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if key, _, ok := strings.Cut(line, ":"); ok {
+			lines = append(lines, key)
+		}
+	}
+	if !sort.StringsAreSorted(lines) {
+		t.Errorf("listConfig() keys not sorted: %v", lines)
+	}
+}
+
+func TestSaveConfigOnlyPersistsFileLayer(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("KITOPS_LOG_LEVEL", "debug")
+
+	opts := &configOptions{configHome: configPath, key: "progress", value: "fancy"}
+	if err := setConfig(nil, opts); err != nil {
+		t.Fatalf("setConfig() error = %v", err)
+	}
+
+	saved, err := loadFileConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadFileConfig() error = %v", err)
+	}
+	if saved.Progress != "fancy" {
+		t.Errorf("saved Progress = %q, want %q", saved.Progress, "fancy")
+	}
+	if saved.LogLevel == "debug" {
+		t.Errorf("saved LogLevel = %q, env-sourced value should not be persisted", saved.LogLevel)
+	}
+}
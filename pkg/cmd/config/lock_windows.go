@@ -0,0 +1,50 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package config
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// withConfigLock runs fn while holding an exclusive advisory lock on
+// configPath+".lock" via LockFileEx, so concurrent `kit config` invocations
+// serialize their read-modify-write instead of racing.
+func withConfigLock(configPath string, fn func() error) error {
+	lockPath := configPath + ".lock"
+	if err := os.MkdirAll(dirOf(lockPath), 0o755); err != nil {
+		return err
+	}
+
+	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer lockFile.Close()
+
+	handle := windows.Handle(lockFile.Fd())
+	overlapped := new(windows.Overlapped)
+	if err := windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		return err
+	}
+	defer windows.UnlockFileEx(handle, 0, 1, 0, overlapped)
+
+	return fn()
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"kitops/pkg/cmd/config/migrations"
+	"os"
+)
+
+// migrateOptions holds arguments for `kit config migrate`.
+type migrateOptions struct {
+	profile    string
+	configHome string
+	dryRun     bool
+}
+
+// readConfigDoc reads configPath and decodes it into a generic document,
+// alongside the raw bytes (needed to write an unmodified backup before any
+// migration touches the document).
+func readConfigDoc(configPath string) (map[string]any, []byte, error) {
+	raw, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := decodeConfigDoc(raw, formatFromExt(configPath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+	return doc, raw, nil
+}
+
+// migrateDocInMemory runs any pending migrations against doc and returns
+// it, without touching disk. This is what read paths (LoadConfig,
+// loadFileConfig) use: they need the upgraded values to resolve keys
+// correctly, but a read must not have the side effect of writing a backup
+// or persisting the migrated file. Writing the upgrade back to disk is the
+// job of the explicit write paths (setConfig, addRegistry,
+// removeRegistryConfig, migrateConfig), via backupIfLegacy + SaveConfig.
+func migrateDocInMemory(doc map[string]any) (map[string]any, error) {
+	if version := schemaVersionOf(doc); version < migrations.CurrentVersion {
+		if _, err := migrations.Run(doc, version); err != nil {
+			return nil, fmt.Errorf("failed to migrate config: %w", err)
+		}
+	}
+	return doc, nil
+}
+
+// loadAndMigrateDoc reads configPath and returns its document with any
+// pending migrations applied in memory (see migrateDocInMemory). A missing
+// file is returned as-is via the os.IsNotExist error so callers can fall
+// back to defaults.
+func loadAndMigrateDoc(configPath string) (map[string]any, error) {
+	doc, _, err := readConfigDoc(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return migrateDocInMemory(doc)
+}
+
+// backupIfLegacy writes a backup of configPath's current contents to
+// "<configPath>.bak.<oldVersion>" if its recorded schema_version is older
+// than migrations.CurrentVersion. Callers that are about to SaveConfig a
+// migrated document call this first, under their existing lock, so the
+// pre-migration file is never lost. A missing file is not an error.
+func backupIfLegacy(configPath string) error {
+	doc, raw, err := readConfigDoc(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if version := schemaVersionOf(doc); version < migrations.CurrentVersion {
+		return backupConfigFile(configPath, raw, version)
+	}
+	return nil
+}
+
+// schemaVersionOf reads the schema_version key from a decoded document,
+// treating a missing key as version 1 (every config file predates
+// schema_version until migration v1->v2 sets it).
+func schemaVersionOf(doc map[string]any) int {
+	switch v := doc["schema_version"].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 1
+	}
+}
+
+// backupConfigFile writes the pre-migration file contents to
+// "<configPath>.bak.<oldVersion>" so a migration can always be undone.
+func backupConfigFile(configPath string, raw []byte, oldVersion int) error {
+	backupPath := fmt.Sprintf("%s.bak.%d", configPath, oldVersion)
+	return os.WriteFile(backupPath, raw, 0o600)
+}
+
+// decodeConfigMap re-encodes doc as JSON and decodes it into cfg, reusing
+// Config's json tags regardless of the document's original source format.
+func decodeConfigMap(doc map[string]any, cfg *Config) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, cfg)
+}
+
+// migrateConfig runs any pending migrations against the profile's config
+// file. With dryRun set, it reports what would change without writing
+// anything.
+func migrateConfig(_ context.Context, opts *migrateOptions) error {
+	configPath := resolveConfigPath(&configOptions{profile: opts.profile, configHome: opts.configHome})
+
+	doc, _, err := readConfigDoc(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("No config file found; nothing to migrate")
+			return nil
+		}
+		return err
+	}
+
+	fromVersion := schemaVersionOf(doc)
+	if fromVersion >= migrations.CurrentVersion {
+		fmt.Printf("Config is already at schema version %d; nothing to migrate\n", migrations.CurrentVersion)
+		return nil
+	}
+
+	if opts.dryRun {
+		fmt.Printf("Would migrate config from schema version %d to %d\n", fromVersion, migrations.CurrentVersion)
+		return nil
+	}
+
+	return withConfigLock(configPath, func() error {
+		if err := backupIfLegacy(configPath); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+		doc, err := loadAndMigrateDoc(configPath)
+		if err != nil {
+			return err
+		}
+		cfg := DefaultConfig()
+		if err := decodeConfigMap(doc, cfg); err != nil {
+			return fmt.Errorf("failed to parse migrated config: %w", err)
+		}
+		if err := SaveConfig(cfg, configPath); err != nil {
+			return err
+		}
+		fmt.Printf("Migrated config from schema version %d to %d\n", fromVersion, migrations.CurrentVersion)
+		return nil
+	})
+}
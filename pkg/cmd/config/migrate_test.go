@@ -0,0 +1,123 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kitops/pkg/cmd/config/migrations"
+)
+
+func writeRawConfig(t *testing.T, path string, doc map[string]any) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal doc: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+}
+
+func TestLoadFileConfigMigratesLegacyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeRawConfig(t, path, map[string]any{"log_level": "warn", "config_dir": "/legacy/home"})
+
+	cfg, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig() error = %v", err)
+	}
+	if cfg.Storage.Location != "/legacy/home" {
+		t.Errorf("Storage.Location = %q, want %q", cfg.Storage.Location, "/legacy/home")
+	}
+	if cfg.SchemaVersion != migrations.CurrentVersion {
+		t.Errorf("SchemaVersion = %d, want %d", cfg.SchemaVersion, migrations.CurrentVersion)
+	}
+
+	// loadFileConfig is a read: it must upgrade the in-memory values but
+	// leave the file (and the on-disk schema_version) untouched, so a
+	// read-only command never has the side effect of writing a backup.
+	backupPath := path + ".bak.1"
+	if _, err := os.Stat(backupPath); !os.IsNotExist(err) {
+		t.Errorf("loadFileConfig should not write a backup file, stat(%s) err = %v", backupPath, err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	var onDisk map[string]any
+	if err := json.Unmarshal(raw, &onDisk); err != nil {
+		t.Fatalf("failed to parse config file: %v", err)
+	}
+	if _, ok := onDisk["storage"]; ok {
+		t.Error("loadFileConfig should not persist the migrated document to disk")
+	}
+}
+
+func TestSetConfigPersistsMigrationWithBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeRawConfig(t, path, map[string]any{"config_dir": "/legacy/home"})
+
+	if err := setConfig(nil, &configOptions{configHome: path, key: "progress", value: "fancy"}); err != nil {
+		t.Fatalf("setConfig() error = %v", err)
+	}
+
+	backupPath := path + ".bak.1"
+	if _, err := os.Stat(backupPath); err != nil {
+		t.Errorf("expected backup file %s to exist: %v", backupPath, err)
+	}
+
+	cfg, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig() error = %v", err)
+	}
+	if cfg.SchemaVersion != migrations.CurrentVersion {
+		t.Errorf("SchemaVersion = %d, want %d (setConfig should persist the migration)", cfg.SchemaVersion, migrations.CurrentVersion)
+	}
+	if cfg.Storage.Location != "/legacy/home" {
+		t.Errorf("Storage.Location = %q, want %q", cfg.Storage.Location, "/legacy/home")
+	}
+	if cfg.Progress != "fancy" {
+		t.Errorf("Progress = %q, want %q", cfg.Progress, "fancy")
+	}
+}
+
+func TestMigrateConfigDryRunDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeRawConfig(t, path, map[string]any{"config_dir": "/legacy/home"})
+
+	if err := migrateConfig(nil, &migrateOptions{dryRun: true, configHome: path}); err != nil {
+		t.Fatalf("migrateConfig() error = %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("failed to parse config file: %v", err)
+	}
+	if _, ok := doc["storage"]; ok {
+		t.Error("dry-run migrateConfig should not modify the file on disk")
+	}
+}
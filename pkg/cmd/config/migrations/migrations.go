@@ -0,0 +1,91 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package migrations holds the ordered steps used to upgrade an on-disk
+// config document from an older schema_version to the one this build of
+// the CLI understands. Each step operates on the raw decoded document
+// (map[string]any) rather than the typed Config struct, since a migration
+// by definition runs before the document necessarily matches today's
+// struct shape.
+package migrations
+
+import "fmt"
+
+// Migration upgrades a config document from schema version From to To.
+type Migration struct {
+	From  int
+	To    int
+	Apply func(doc map[string]any) error
+}
+
+// CurrentVersion is the schema_version written by this build of the CLI.
+// Bump it, and add a Migration to All, whenever Config's on-disk shape
+// changes in a way older files won't already match.
+const CurrentVersion = 2
+
+// All holds the migrations in order. LoadConfig (via Run) walks them
+// starting from a file's recorded schema_version until it reaches
+// CurrentVersion.
+var All = []Migration{
+	{From: 1, To: 2, Apply: migrateV1ToV2},
+}
+
+// migrateV1ToV2 moves the flat top-level config_dir key into
+// storage.location, matching the nested sections introduced alongside
+// schema_version 2.
+func migrateV1ToV2(doc map[string]any) error {
+	dir, ok := doc["config_dir"]
+	if !ok {
+		return nil
+	}
+	dirStr, ok := dir.(string)
+	if !ok || dirStr == "" {
+		delete(doc, "config_dir")
+		return nil
+	}
+
+	storage, _ := doc["storage"].(map[string]any)
+	if storage == nil {
+		storage = map[string]any{}
+	}
+	if _, exists := storage["location"]; !exists {
+		storage["location"] = dirStr
+	}
+	doc["storage"] = storage
+	delete(doc, "config_dir")
+	return nil
+}
+
+// Run applies every migration starting at fromVersion in sequence,
+// recording the resulting version as doc's schema_version and returning
+// it. If fromVersion is already >= CurrentVersion, doc is left unmodified.
+func Run(doc map[string]any, fromVersion int) (int, error) {
+	if fromVersion >= CurrentVersion {
+		return fromVersion, nil
+	}
+	version := fromVersion
+	for _, m := range All {
+		if m.From != version {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return version, fmt.Errorf("migration %d -> %d failed: %w", m.From, m.To, err)
+		}
+		version = m.To
+	}
+	doc["schema_version"] = version
+	return version, nil
+}
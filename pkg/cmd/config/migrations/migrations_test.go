@@ -0,0 +1,70 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package migrations
+
+import "testing"
+
+func TestRunMigratesV1ToCurrent(t *testing.T) {
+	doc := map[string]any{"config_dir": "/home/me/.kitops"}
+
+	version, err := Run(doc, 1)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if version != CurrentVersion {
+		t.Errorf("version = %d, want %d", version, CurrentVersion)
+	}
+	if _, ok := doc["config_dir"]; ok {
+		t.Error("Run() should remove the legacy config_dir key")
+	}
+	storage, ok := doc["storage"].(map[string]any)
+	if !ok {
+		t.Fatalf("Run() should add a storage map, got %T", doc["storage"])
+	}
+	if storage["location"] != "/home/me/.kitops" {
+		t.Errorf("storage.location = %v, want %q", storage["location"], "/home/me/.kitops")
+	}
+}
+
+func TestRunNoOpAtCurrentVersion(t *testing.T) {
+	doc := map[string]any{"log_level": "debug"}
+
+	version, err := Run(doc, CurrentVersion)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if version != CurrentVersion {
+		t.Errorf("version = %d, want %d", version, CurrentVersion)
+	}
+	if doc["log_level"] != "debug" {
+		t.Errorf("Run() should leave unrelated keys untouched")
+	}
+}
+
+func TestRunDoesNotOverwriteExistingStorageLocation(t *testing.T) {
+	doc := map[string]any{
+		"config_dir": "/legacy/path",
+		"storage":    map[string]any{"location": "/already/set"},
+	}
+
+	if _, err := Run(doc, 1); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	storage := doc["storage"].(map[string]any)
+	if storage["location"] != "/already/set" {
+		t.Errorf("storage.location = %v, want existing value preserved", storage["location"])
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// getPath resolves a dotted path such as "storage.location" or
+// "registries.dockerhub.auth.username" against cfg. Unlike
+// reflect.FieldByName(strings.Title(...)), each segment is matched
+// explicitly against the struct's known keys, so snake_case keys and list
+// lookups by name both work.
+func getPath(cfg *Config, segments []string) (string, error) {
+	switch segments[0] {
+	case "storage":
+		return getStoragePath(&cfg.Storage, segments[1:])
+	case "logging":
+		return getLoggingPath(&cfg.Logging, segments[1:])
+	case "registries":
+		reg, rest, err := findRegistry(cfg, segments[1:])
+		if err != nil {
+			return "", err
+		}
+		return getAuthPath(reg, rest)
+	default:
+		return "", fmt.Errorf("unknown configuration path: %s", strings.Join(segments, "."))
+	}
+}
+
+// setPath is the inverse of getPath: it walks to the addressed field and
+// assigns value, creating a new registry entry if the path names one that
+// doesn't exist yet.
+func setPath(cfg *Config, segments []string, value string) error {
+	switch segments[0] {
+	case "storage":
+		return setStoragePath(&cfg.Storage, segments[1:], value)
+	case "logging":
+		return setLoggingPath(&cfg.Logging, segments[1:], value)
+	case "registries":
+		reg, rest, err := findOrCreateRegistry(cfg, segments[1:])
+		if err != nil {
+			return err
+		}
+		return setAuthPath(reg, rest, value)
+	default:
+		return fmt.Errorf("unknown configuration path: %s", strings.Join(segments, "."))
+	}
+}
+
+func getStoragePath(s *StorageConfig, segments []string) (string, error) {
+	if len(segments) == 0 {
+		return "", fmt.Errorf("path must name a field under storage")
+	}
+	switch segments[0] {
+	case "location":
+		return s.Location, nil
+	case "gc":
+		if len(segments) < 2 {
+			return "", fmt.Errorf("path must name a field under storage.gc")
+		}
+		switch segments[1] {
+		case "enabled":
+			if s.GC.Enabled == nil {
+				return "", nil
+			}
+			return strconv.FormatBool(*s.GC.Enabled), nil
+		case "ttl":
+			return s.GC.TTL, nil
+		}
+	}
+	return "", fmt.Errorf("unknown configuration path: storage.%s", strings.Join(segments, "."))
+}
+
+func setStoragePath(s *StorageConfig, segments []string, value string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("path must name a field under storage")
+	}
+	switch segments[0] {
+	case "location":
+		s.Location = value
+		return nil
+	case "gc":
+		if len(segments) < 2 {
+			return fmt.Errorf("path must name a field under storage.gc")
+		}
+		switch segments[1] {
+		case "enabled":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("storage.gc.enabled must be a boolean: %w", err)
+			}
+			s.GC.Enabled = &enabled
+			return nil
+		case "ttl":
+			s.GC.TTL = value
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown configuration path: storage.%s", strings.Join(segments, "."))
+}
+
+func getLoggingPath(l *LoggingConfig, segments []string) (string, error) {
+	if len(segments) != 1 {
+		return "", fmt.Errorf("path must name a single field under logging")
+	}
+	switch segments[0] {
+	case "level":
+		return l.Level, nil
+	case "format":
+		return l.Format, nil
+	case "file":
+		return l.File, nil
+	case "time_format":
+		return l.TimeFormat, nil
+	}
+	return "", fmt.Errorf("unknown configuration path: logging.%s", segments[0])
+}
+
+func setLoggingPath(l *LoggingConfig, segments []string, value string) error {
+	if len(segments) != 1 {
+		return fmt.Errorf("path must name a single field under logging")
+	}
+	switch segments[0] {
+	case "level":
+		l.Level = value
+	case "format":
+		l.Format = value
+	case "file":
+		l.File = value
+	case "time_format":
+		l.TimeFormat = value
+	default:
+		return fmt.Errorf("unknown configuration path: logging.%s", segments[0])
+	}
+	return nil
+}
+
+// findRegistry looks up the registry named segments[0], returning it and
+// the remaining path segments.
+func findRegistry(cfg *Config, segments []string) (*RegistryConfig, []string, error) {
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("path must name a registry")
+	}
+	name := segments[0]
+	for i := range cfg.Registries {
+		if cfg.Registries[i].Name == name {
+			return &cfg.Registries[i], segments[1:], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unknown registry: %s", name)
+}
+
+// findOrCreateRegistry is like findRegistry but appends a new entry when
+// name isn't already present, so `kit config set registries.dockerhub.url
+// ...` works without a separate create step.
+func findOrCreateRegistry(cfg *Config, segments []string) (*RegistryConfig, []string, error) {
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("path must name a registry")
+	}
+	name := segments[0]
+	for i := range cfg.Registries {
+		if cfg.Registries[i].Name == name {
+			return &cfg.Registries[i], segments[1:], nil
+		}
+	}
+	cfg.Registries = append(cfg.Registries, RegistryConfig{Name: name})
+	return &cfg.Registries[len(cfg.Registries)-1], segments[1:], nil
+}
+
+func getAuthPath(reg *RegistryConfig, segments []string) (string, error) {
+	if len(segments) == 0 {
+		return "", fmt.Errorf("path must name a field on registry %s", reg.Name)
+	}
+	switch segments[0] {
+	case "url":
+		return reg.URL, nil
+	case "auth":
+		if len(segments) != 2 {
+			return "", fmt.Errorf("path must name a single field under registries.%s.auth", reg.Name)
+		}
+		switch segments[1] {
+		case "type":
+			return reg.Auth.Type, nil
+		case "username":
+			return reg.Auth.Username, nil
+		case "password_env":
+			return reg.Auth.PasswordEnv, nil
+		case "token_env":
+			return reg.Auth.TokenEnv, nil
+		case "ssh_key_path":
+			return reg.Auth.SSHKeyPath, nil
+		case "ssh_key_passphrase_env":
+			return reg.Auth.SSHKeyPassphraseEnv, nil
+		}
+	}
+	return "", fmt.Errorf("unknown configuration path: registries.%s.%s", reg.Name, strings.Join(segments, "."))
+}
+
+func setAuthPath(reg *RegistryConfig, segments []string, value string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("path must name a field on registry %s", reg.Name)
+	}
+	switch segments[0] {
+	case "url":
+		reg.URL = value
+		return nil
+	case "auth":
+		if len(segments) != 2 {
+			return fmt.Errorf("path must name a single field under registries.%s.auth", reg.Name)
+		}
+		switch segments[1] {
+		case "type":
+			reg.Auth.Type = value
+		case "username":
+			reg.Auth.Username = value
+		case "password_env":
+			reg.Auth.PasswordEnv = value
+		case "token_env":
+			reg.Auth.TokenEnv = value
+		case "ssh_key_path":
+			reg.Auth.SSHKeyPath = value
+		case "ssh_key_passphrase_env":
+			reg.Auth.SSHKeyPassphraseEnv = value
+		default:
+			return fmt.Errorf("unknown configuration path: registries.%s.auth.%s", reg.Name, segments[1])
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown configuration path: registries.%s.%s", reg.Name, strings.Join(segments, "."))
+}
+
+// removeRegistry deletes the registry named name, returning an error if it
+// isn't present.
+func removeRegistry(cfg *Config, name string) error {
+	for i := range cfg.Registries {
+		if cfg.Registries[i].Name == name {
+			cfg.Registries = append(cfg.Registries[:i], cfg.Registries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown registry: %s", name)
+}
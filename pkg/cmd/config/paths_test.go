@@ -0,0 +1,81 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import "testing"
+
+func TestConfigSetGetNestedPath(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.Set("storage.location", "/data/kitops"); err != nil {
+		t.Fatalf("Set(storage.location) error = %v", err)
+	}
+	got, err := cfg.Get("storage.location")
+	if err != nil {
+		t.Fatalf("Get(storage.location) error = %v", err)
+	}
+	if got != "/data/kitops" {
+		t.Errorf("storage.location = %q, want %q", got, "/data/kitops")
+	}
+
+	if err := cfg.Set("storage.gc.enabled", "true"); err != nil {
+		t.Fatalf("Set(storage.gc.enabled) error = %v", err)
+	}
+	if got, _ := cfg.Get("storage.gc.enabled"); got != "true" {
+		t.Errorf("storage.gc.enabled = %q, want %q", got, "true")
+	}
+}
+
+func TestConfigSetGetRegistryPath(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.Set("registries.dockerhub.url", "https://index.docker.io"); err != nil {
+		t.Fatalf("Set(registries.dockerhub.url) error = %v", err)
+	}
+	if err := cfg.Set("registries.dockerhub.auth.token_env", "KITOPS_DOCKERHUB_TOKEN"); err != nil {
+		t.Fatalf("Set(registries.dockerhub.auth.token_env) error = %v", err)
+	}
+
+	if len(cfg.Registries) != 1 {
+		t.Fatalf("len(Registries) = %d, want 1", len(cfg.Registries))
+	}
+	got, err := cfg.Get("registries.dockerhub.auth.token_env")
+	if err != nil {
+		t.Fatalf("Get(registries.dockerhub.auth.token_env) error = %v", err)
+	}
+	if got != "KITOPS_DOCKERHUB_TOKEN" {
+		t.Errorf("token_env = %q, want %q", got, "KITOPS_DOCKERHUB_TOKEN")
+	}
+
+	if _, err := cfg.Get("registries.nonexistent.url"); err == nil {
+		t.Error("Get() on an unknown registry should return an error")
+	}
+}
+
+func TestRemoveRegistry(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Registries = []RegistryConfig{{Name: "dockerhub", URL: "https://index.docker.io"}}
+
+	if err := removeRegistry(cfg, "dockerhub"); err != nil {
+		t.Fatalf("removeRegistry() error = %v", err)
+	}
+	if len(cfg.Registries) != 0 {
+		t.Errorf("len(Registries) = %d, want 0", len(cfg.Registries))
+	}
+	if err := removeRegistry(cfg, "dockerhub"); err == nil {
+		t.Error("removeRegistry() on an already-removed registry should return an error")
+	}
+}
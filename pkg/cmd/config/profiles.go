@@ -0,0 +1,286 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// profileOptions holds arguments for the `kit config profile` subcommands.
+type profileOptions struct {
+	name    string
+	parent  string
+	baseDir string // KITOPS_HOME, or its default
+}
+
+// State records global, non-profile-specific CLI state such as which
+// profile is currently active. It lives at $KITOPS_HOME/state.json,
+// alongside (not inside) the profiles/ directory.
+type State struct {
+	ActiveProfile string `json:"active_profile"`
+}
+
+// createProfile creates a new profile directory with its own config.json.
+// If parent is set, the profile's config.json records an `inherits` pointer
+// instead of a full copy of the parent's values.
+func createProfile(_ context.Context, opts *profileOptions) error {
+	profileDir := filepath.Join(opts.baseDir, "profiles", opts.name)
+	if _, err := os.Stat(profileDir); err == nil {
+		return fmt.Errorf("profile %q already exists", opts.name)
+	}
+
+	if opts.parent != "" {
+		if _, err := resolveProfileChain(opts.baseDir, opts.parent); err != nil {
+			return fmt.Errorf("invalid parent profile: %w", err)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.Inherits = opts.parent
+	configPath := filepath.Join(profileDir, "config.json")
+	if err := SaveConfig(cfg, configPath); err != nil {
+		return fmt.Errorf("failed to create profile %q: %w", opts.name, err)
+	}
+	fmt.Printf("Created profile %q\n", opts.name)
+	return nil
+}
+
+// listProfiles prints the names of all profiles under baseDir, marking the
+// active one.
+func listProfiles(_ context.Context, opts *profileOptions) error {
+	profilesDir := filepath.Join(opts.baseDir, "profiles")
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	state, err := loadState(opts.baseDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		marker := " "
+		if entry.Name() == state.ActiveProfile {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, entry.Name())
+	}
+	return nil
+}
+
+// useProfile sets name as the active profile, recorded in state.json.
+func useProfile(_ context.Context, opts *profileOptions) error {
+	if opts.name != "" {
+		profileDir := filepath.Join(opts.baseDir, "profiles", opts.name)
+		if _, err := os.Stat(profileDir); err != nil {
+			return fmt.Errorf("profile %q does not exist", opts.name)
+		}
+	}
+
+	state, err := loadState(opts.baseDir)
+	if err != nil {
+		return err
+	}
+	state.ActiveProfile = opts.name
+	if err := saveState(opts.baseDir, state); err != nil {
+		return err
+	}
+	if opts.name == "" {
+		fmt.Println("Cleared active profile")
+	} else {
+		fmt.Printf("Active profile set to %q\n", opts.name)
+	}
+	return nil
+}
+
+// deleteProfile removes a profile's directory. It refuses to delete a
+// profile that other profiles inherit from.
+func deleteProfile(_ context.Context, opts *profileOptions) error {
+	entries, err := os.ReadDir(filepath.Join(opts.baseDir, "profiles"))
+	if err == nil {
+		for _, entry := range entries {
+			if entry.Name() == opts.name {
+				continue
+			}
+			cfg, err := loadFileConfig(filepath.Join(opts.baseDir, "profiles", entry.Name(), "config.json"))
+			if err == nil && cfg.Inherits == opts.name {
+				return fmt.Errorf("cannot delete profile %q: profile %q inherits from it", opts.name, entry.Name())
+			}
+		}
+	}
+
+	profileDir := filepath.Join(opts.baseDir, "profiles", opts.name)
+	if err := os.RemoveAll(profileDir); err != nil {
+		return fmt.Errorf("failed to delete profile %q: %w", opts.name, err)
+	}
+
+	state, err := loadState(opts.baseDir)
+	if err != nil {
+		return err
+	}
+	if state.ActiveProfile == opts.name {
+		state.ActiveProfile = ""
+		if err := saveState(opts.baseDir, state); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Deleted profile %q\n", opts.name)
+	return nil
+}
+
+// showProfile prints the fully-merged configuration for a profile, after
+// walking its inheritance chain.
+func showProfile(_ context.Context, opts *profileOptions) error {
+	cfg, err := loadMergedProfileConfig(opts.baseDir, opts.name)
+	if err != nil {
+		return err
+	}
+	for _, key := range Keys() {
+		value, err := cfg.Get(key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %v\n", key, value)
+	}
+	return nil
+}
+
+// loadMergedProfileConfig walks profile's inheritance chain (parent first)
+// and merges each config.json on top of the last, so that a child profile
+// only needs to specify the keys that diverge from its parent.
+func loadMergedProfileConfig(baseDir, profile string) (*Config, error) {
+	chain, err := resolveProfileChain(baseDir, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	for _, name := range chain {
+		layer, err := loadFileConfig(filepath.Join(baseDir, "profiles", name, "config.json"))
+		if err != nil {
+			return nil, err
+		}
+		cfg = mergeConfig(cfg, layer)
+	}
+	return cfg, nil
+}
+
+// resolveProfileChain returns the chain of profile names from the root
+// ancestor down to profile (inclusive), detecting inheritance cycles.
+func resolveProfileChain(baseDir, profile string) ([]string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+
+	for name := profile; name != ""; {
+		if seen[name] {
+			return nil, fmt.Errorf("inheritance cycle detected at profile %q", name)
+		}
+		seen[name] = true
+
+		cfg, err := loadFileConfig(filepath.Join(baseDir, "profiles", name, "config.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile %q: %w", name, err)
+		}
+		chain = append([]string{name}, chain...)
+		name = cfg.Inherits
+	}
+	return chain, nil
+}
+
+// mergeConfig overlays the non-zero fields of override onto base and
+// returns the result. Used to apply a profile's overrides on top of its
+// parent's merged config.
+func mergeConfig(base, override *Config) *Config {
+	merged := *base
+	if override.LogLevel != "" {
+		merged.LogLevel = override.LogLevel
+	}
+	if override.Progress != "" {
+		merged.Progress = override.Progress
+	}
+	if override.ConfigDir != "" {
+		merged.ConfigDir = override.ConfigDir
+	}
+	if len(override.Registries) > 0 {
+		merged.Registries = override.Registries
+	}
+	if override.Storage.Location != "" {
+		merged.Storage.Location = override.Storage.Location
+	}
+	if override.Storage.GC.Enabled != nil {
+		merged.Storage.GC.Enabled = override.Storage.GC.Enabled
+	}
+	if override.Storage.GC.TTL != "" {
+		merged.Storage.GC.TTL = override.Storage.GC.TTL
+	}
+	if override.Logging.Level != "" {
+		merged.Logging.Level = override.Logging.Level
+	}
+	if override.Logging.Format != "" {
+		merged.Logging.Format = override.Logging.Format
+	}
+	if override.Logging.File != "" {
+		merged.Logging.File = override.Logging.File
+	}
+	if override.Logging.TimeFormat != "" {
+		merged.Logging.TimeFormat = override.Logging.TimeFormat
+	}
+	merged.Inherits = override.Inherits
+	return &merged
+}
+
+func loadState(baseDir string) (*State, error) {
+	path := filepath.Join(baseDir, "state.json")
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var state State
+	if err := json.NewDecoder(file).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+func saveState(baseDir string, state *State) error {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, "state.json")
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(state)
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileInheritance(t *testing.T) {
+	baseDir := t.TempDir()
+
+	parent := DefaultConfig()
+	parent.LogLevel = "warn"
+	if err := SaveConfig(parent, filepath.Join(baseDir, "profiles", "base", "config.json")); err != nil {
+		t.Fatalf("failed to write parent profile: %v", err)
+	}
+
+	child := DefaultConfig()
+	child.Inherits = "base"
+	child.Progress = "fancy"
+	if err := SaveConfig(child, filepath.Join(baseDir, "profiles", "dev", "config.json")); err != nil {
+		t.Fatalf("failed to write child profile: %v", err)
+	}
+
+	merged, err := loadMergedProfileConfig(baseDir, "dev")
+	if err != nil {
+		t.Fatalf("loadMergedProfileConfig() error = %v", err)
+	}
+	if merged.LogLevel != "warn" {
+		t.Errorf("LogLevel = %q, want %q (inherited from parent)", merged.LogLevel, "warn")
+	}
+	if merged.Progress != "fancy" {
+		t.Errorf("Progress = %q, want %q (overridden by child)", merged.Progress, "fancy")
+	}
+}
+
+func TestMergeConfigPreservesSiblingNestedFields(t *testing.T) {
+	base := DefaultConfig()
+	base.Logging = LoggingConfig{Level: "warn", File: "/var/log/kit.log"}
+	base.Storage.GC = GCConfig{Enabled: boolPtr(true), TTL: "24h"}
+
+	override := DefaultConfig()
+	override.Logging = LoggingConfig{Format: "json"}
+	override.Storage.GC = GCConfig{TTL: "1h"}
+
+	merged := mergeConfig(base, override)
+
+	if merged.Logging.Format != "json" {
+		t.Errorf("Logging.Format = %q, want %q (overridden)", merged.Logging.Format, "json")
+	}
+	if merged.Logging.Level != "warn" {
+		t.Errorf("Logging.Level = %q, want %q (parent's value should survive a format-only override)", merged.Logging.Level, "warn")
+	}
+	if merged.Logging.File != "/var/log/kit.log" {
+		t.Errorf("Logging.File = %q, want %q (parent's value should survive a format-only override)", merged.Logging.File, "/var/log/kit.log")
+	}
+	if merged.Storage.GC.TTL != "1h" {
+		t.Errorf("Storage.GC.TTL = %q, want %q (overridden)", merged.Storage.GC.TTL, "1h")
+	}
+	if merged.Storage.GC.Enabled == nil || !*merged.Storage.GC.Enabled {
+		t.Error("Storage.GC.Enabled should survive a TTL-only override")
+	}
+}
+
+// TestMergeConfigOverridesGCEnabledFalse covers the case a plain bool can't:
+// a child profile explicitly turning off a gc.enabled that its parent turned
+// on. Distinguishing "override set to false" from "override didn't mention
+// it" is the entire reason GCConfig.Enabled is a *bool.
+func TestMergeConfigOverridesGCEnabledFalse(t *testing.T) {
+	base := DefaultConfig()
+	base.Storage.GC = GCConfig{Enabled: boolPtr(true), TTL: "24h"}
+
+	override := DefaultConfig()
+	override.Storage.GC = GCConfig{Enabled: boolPtr(false)}
+
+	merged := mergeConfig(base, override)
+
+	if merged.Storage.GC.Enabled == nil || *merged.Storage.GC.Enabled {
+		t.Error("Storage.GC.Enabled should be overridden to false")
+	}
+	if merged.Storage.GC.TTL != "24h" {
+		t.Errorf("Storage.GC.TTL = %q, want %q (parent's value should survive an enabled-only override)", merged.Storage.GC.TTL, "24h")
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestProfileInheritanceCycleDetected(t *testing.T) {
+	baseDir := t.TempDir()
+
+	a := DefaultConfig()
+	a.Inherits = "b"
+	if err := SaveConfig(a, filepath.Join(baseDir, "profiles", "a", "config.json")); err != nil {
+		t.Fatalf("failed to write profile a: %v", err)
+	}
+	b := DefaultConfig()
+	b.Inherits = "a"
+	if err := SaveConfig(b, filepath.Join(baseDir, "profiles", "b", "config.json")); err != nil {
+		t.Fatalf("failed to write profile b: %v", err)
+	}
+
+	if _, err := resolveProfileChain(baseDir, "a"); err == nil {
+		t.Error("resolveProfileChain() should detect the a -> b -> a cycle")
+	}
+}
+
+func TestUseProfileRecordsActiveProfile(t *testing.T) {
+	baseDir := t.TempDir()
+	if err := SaveConfig(DefaultConfig(), filepath.Join(baseDir, "profiles", "staging", "config.json")); err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+
+	if err := useProfile(nil, &profileOptions{baseDir: baseDir, name: "staging"}); err != nil {
+		t.Fatalf("useProfile() error = %v", err)
+	}
+
+	state, err := loadState(baseDir)
+	if err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	if state.ActiveProfile != "staging" {
+		t.Errorf("ActiveProfile = %q, want %q", state.ActiveProfile, "staging")
+	}
+}
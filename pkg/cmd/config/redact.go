@@ -0,0 +1,67 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// authFields lists a registry's auth settings as "key: value" strings. A
+// field tagged sensitive:"true" never holds a secret directly — it names
+// an env var to read one from — so it's always printed as
+// "<from env NAME>", regardless of showSecrets: there is no stored secret
+// value for showSecrets to reveal, only the env var name, which is shown
+// either way. showSecrets is accepted for symmetry with the non-sensitive
+// fields and so a future field that does hold a real secret can honor it.
+// Driven by the struct tag rather than a hardcoded field list, so a new
+// sensitive field added to AuthConfig is handled automatically.
+func authFields(auth AuthConfig, showSecrets bool) []string {
+	var lines []string
+	v := reflect.ValueOf(auth)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i).String()
+		if value == "" {
+			continue
+		}
+		jsonKey := fieldJSONName(field)
+		if jsonKey == "" {
+			continue
+		}
+		if field.Tag.Get("sensitive") == "true" {
+			value = fmt.Sprintf("<from env %s>", value)
+		}
+		lines = append(lines, fmt.Sprintf("auth.%s: %s", jsonKey, value))
+	}
+	return lines
+}
+
+// fieldJSONName returns the base name from a field's json tag (ignoring
+// ",omitempty" and similar options), or "" if the field has no json tag.
+func fieldJSONName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "" || tag == "-" {
+		return ""
+	}
+	for i, c := range tag {
+		if c == ',' {
+			return tag[:i]
+		}
+	}
+	return tag
+}
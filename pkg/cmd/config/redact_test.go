@@ -0,0 +1,55 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuthFieldsNamesEnvVarByDefault(t *testing.T) {
+	auth := AuthConfig{Type: "token", Username: "alice", TokenEnv: "KITOPS_DOCKERHUB_TOKEN"}
+
+	lines := authFields(auth, false)
+	joined := strings.Join(lines, "\n")
+	// token_env only ever names an env var, never a secret value directly,
+	// so showing which env var to set is safe and is the whole point of
+	// the field - only the resolved secret itself needs hiding.
+	if !strings.Contains(joined, "auth.token_env: <from env KITOPS_DOCKERHUB_TOKEN>") {
+		t.Errorf("authFields() = %q, want an auth.token_env line naming the env var", joined)
+	}
+	if !strings.Contains(joined, "auth.username: alice") {
+		t.Errorf("authFields() should not redact the non-sensitive username field: %s", joined)
+	}
+}
+
+func TestAuthFieldsShowSecretsDoesNotResolveEnvVar(t *testing.T) {
+	// token_env only ever stores the name of an env var, never a secret
+	// value, so --show-secrets has nothing further to reveal: it must not
+	// dereference the env var and print whatever secret it currently
+	// holds.
+	t.Setenv("KITOPS_DOCKERHUB_TOKEN", "super-secret-token")
+	auth := AuthConfig{TokenEnv: "KITOPS_DOCKERHUB_TOKEN"}
+
+	lines := authFields(auth, true)
+	joined := strings.Join(lines, "\n")
+	if strings.Contains(joined, "super-secret-token") {
+		t.Errorf("authFields() with showSecrets=true leaked the resolved env var value: %s", joined)
+	}
+	if !strings.Contains(joined, "auth.token_env: <from env KITOPS_DOCKERHUB_TOKEN>") {
+		t.Errorf("authFields() = %q, want the same <from env NAME> line as showSecrets=false", joined)
+	}
+}
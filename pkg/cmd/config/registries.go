@@ -0,0 +1,82 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"context"
+	"fmt"
+)
+
+// addRegistry appends (or, if name already exists, replaces) a registry
+// entry in the file layer. The read-modify-write is done under an advisory
+// lock so a concurrent `kit config` invocation can't interleave and lose an
+// update.
+func addRegistry(_ context.Context, opts *configOptions, reg RegistryConfig) error {
+	if reg.Name == "" {
+		return fmt.Errorf("registry name is required")
+	}
+	configPath := resolveConfigPath(opts)
+	return withConfigLock(configPath, func() error {
+		if err := backupIfLegacy(configPath); err != nil {
+			return err
+		}
+		cfg, err := loadFileConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		replaced := false
+		for i := range cfg.Registries {
+			if cfg.Registries[i].Name == reg.Name {
+				cfg.Registries[i] = reg
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			cfg.Registries = append(cfg.Registries, reg)
+		}
+
+		if err := SaveConfig(cfg, configPath); err != nil {
+			return err
+		}
+		fmt.Printf("Registry %q saved\n", reg.Name)
+		return nil
+	})
+}
+
+// removeRegistryConfig deletes a registry entry named name from the file
+// layer, under the same advisory lock as addRegistry.
+func removeRegistryConfig(_ context.Context, opts *configOptions, name string) error {
+	configPath := resolveConfigPath(opts)
+	return withConfigLock(configPath, func() error {
+		if err := backupIfLegacy(configPath); err != nil {
+			return err
+		}
+		cfg, err := loadFileConfig(configPath)
+		if err != nil {
+			return err
+		}
+		if err := removeRegistry(cfg, name); err != nil {
+			return err
+		}
+		if err := SaveConfig(cfg, configPath); err != nil {
+			return err
+		}
+		fmt.Printf("Registry %q removed\n", name)
+		return nil
+	})
+}
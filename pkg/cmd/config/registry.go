@@ -0,0 +1,85 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// configKey describes a single top-level configuration key: how to read and
+// write it on a *Config. Using an explicit registry instead of
+// reflect.FieldByName(strings.Title(key)) means keys with underscores (e.g.
+// "log_level") resolve correctly, and looking up an unknown key is a normal
+// map miss rather than a zero Value that panics on SetString.
+type configKey struct {
+	get func(*Config) string
+	set func(*Config, string) error
+}
+
+var keyRegistry = map[string]configKey{
+	"log_level": {
+		get: func(c *Config) string { return c.LogLevel },
+		set: func(c *Config, v string) error { c.LogLevel = v; return nil },
+	},
+	"progress": {
+		get: func(c *Config) string { return c.Progress },
+		set: func(c *Config, v string) error { c.Progress = v; return nil },
+	},
+	"config_dir": {
+		get: func(c *Config) string { return c.ConfigDir },
+		set: func(c *Config, v string) error { c.ConfigDir = v; return nil },
+	},
+}
+
+// Get returns the value of key on this Config. key may be a flat top-level
+// key ("log_level") or a dotted path into a nested section or list entry
+// ("storage.location", "registries.dockerhub.auth.username").
+func (c *Config) Get(key string) (string, error) {
+	if strings.Contains(key, ".") {
+		return getPath(c, strings.Split(key, "."))
+	}
+	k, ok := keyRegistry[key]
+	if !ok {
+		return "", fmt.Errorf("unknown configuration key: %s", key)
+	}
+	return k.get(c), nil
+}
+
+// Set updates the value of key on this Config. key may be a flat top-level
+// key or a dotted path; see Get.
+func (c *Config) Set(key, value string) error {
+	if strings.Contains(key, ".") {
+		return setPath(c, strings.Split(key, "."), value)
+	}
+	k, ok := keyRegistry[key]
+	if !ok {
+		return fmt.Errorf("unknown configuration key: %s", key)
+	}
+	return k.set(c, value)
+}
+
+// Keys returns the list of known configuration keys, sorted for
+// deterministic output, for help text, completions, and listConfig.
+func Keys() []string {
+	keys := make([]string, 0, len(keyRegistry))
+	for k := range keyRegistry {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
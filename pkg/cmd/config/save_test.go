@@ -0,0 +1,74 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveConfigLeavesNoTempFileBehind(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := SaveConfig(&Config{LogLevel: "debug"}, path); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("SaveConfig() left a temp file behind: %v", err)
+	}
+	cfg, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("LogLevel = %q, want %q", cfg.LogLevel, "debug")
+	}
+}
+
+func TestWithConfigLockSerializesWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- withConfigLock(path, func() error {
+				cfg, err := loadFileConfig(path)
+				if err != nil {
+					return err
+				}
+				cfg.Progress = "fancy"
+				return SaveConfig(cfg, path)
+			})
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("withConfigLock() error = %v", err)
+		}
+	}
+
+	cfg, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig() error = %v", err)
+	}
+	if cfg.Progress != "fancy" {
+		t.Errorf("Progress = %q, want %q", cfg.Progress, "fancy")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+//go:embed schema/config.schema.json
+var configSchemaJSON []byte
+
+// validateOptions holds arguments for `kit config validate`.
+type validateOptions struct {
+	path string // file to validate; "" means stdin
+}
+
+// validateConfig checks a config file (or stdin) against the embedded JSON
+// Schema, returning a structured error naming every violation found so CI
+// pipelines can lint dotfiles before deployment.
+func validateConfig(_ context.Context, opts *validateOptions) error {
+	var raw []byte
+	var err error
+	if opts.path == "" || opts.path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(opts.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	f := formatFromExt(opts.path)
+	// Validate the raw decoded document, not a round-trip through Config:
+	// unmarshaling into the typed struct first would silently drop any key
+	// Config doesn't know about, so a typo like "log_levl" would never
+	// reach additionalProperties:false.
+	asMap, err := decodeConfigDoc(raw, f)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	schemaLoader := gojsonschema.NewBytesLoader(configSchemaJSON)
+	docLoader := gojsonschema.NewGoLoader(asMap)
+	result, err := gojsonschema.Validate(schemaLoader, docLoader)
+	if err != nil {
+		return fmt.Errorf("failed to run schema validation: %w", err)
+	}
+	if !result.Valid() {
+		msg := "config is invalid:"
+		for _, desc := range result.Errors() {
+			msg += fmt.Sprintf("\n  - %s: %s", desc.Field(), desc.Description())
+		}
+		return fmt.Errorf("%s", msg)
+	}
+
+	fmt.Println("Config is valid")
+	return nil
+}
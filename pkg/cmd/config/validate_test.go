@@ -0,0 +1,73 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateConfigRejectsBadEnum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"log_level":"verbose","progress":"plain"}`), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	err := validateConfig(nil, &validateOptions{path: path})
+	if err == nil {
+		t.Error("validateConfig() should reject an unknown log_level enum value")
+	}
+}
+
+func TestValidateConfigRejectsUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	// "log_levl" is a typo for "log_level"; decoding it into a Config
+	// struct first (rather than the raw document) would silently drop it
+	// and let this slip past additionalProperties:false.
+	if err := os.WriteFile(path, []byte(`{"log_levl":"debug"}`), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	err := validateConfig(nil, &validateOptions{path: path})
+	if err == nil {
+		t.Error("validateConfig() should reject an unknown config key")
+	}
+}
+
+func TestValidateConfigAcceptsCLIWrittenYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := DefaultConfig()
+	cfg.LogLevel = "debug"
+	if err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	if err := validateConfig(nil, &validateOptions{path: path}); err != nil {
+		t.Errorf("validateConfig() error = %v, want nil (a config the CLI wrote itself should always validate)", err)
+	}
+}
+
+func TestValidateConfigAcceptsValidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"log_level":"debug","progress":"fancy"}`), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if err := validateConfig(nil, &validateOptions{path: path}); err != nil {
+		t.Errorf("validateConfig() error = %v, want nil", err)
+	}
+}